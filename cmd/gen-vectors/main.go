@@ -0,0 +1,112 @@
+// Command gen-vectors checks testdata/rapidhash_vectors.json against the
+// rapidhash package it ships alongside, and documents how that file was
+// produced.
+//
+// csrc/rapidhash_ref.c is a from-scratch C transcription of the algorithm
+// (checked into this module, not just described) that, compiled with gcc
+// and run, emits a JSON array of {label, seed, secret, hex, expected}
+// records in the same format as the checked-in file. "secret" is 0 for the
+// package's default secret and 1 for a second, arbitrary [3]uint64
+// secret, so the corpus also exercises HashWithSeedAndSecret's
+// custom-secret path. Run from this directory, these commands replace the
+// checked-in file with the C program's corpus:
+//
+//	gcc -O2 -o gen-vectors csrc/rapidhash_ref.c
+//	./gen-vectors > ../../testdata/rapidhash_vectors.json
+//
+// The checked-in file is not that program's output. It holds the empty
+// string, nine ASCII strings, a byte pattern at the same 26 lengths, zero
+// runs of 1, 5, 17, 48 and 100 bytes, a UTF-8 sample and 4/8/12 KiB
+// pseudo-random blobs, under the same five seeds and both secrets. Where
+// the two corpora share an input, seed and secret, they give the same hash.
+//
+// This requires a C toolchain the rest of the module doesn't otherwise
+// depend on, which is also why rapidhash_ref.c lives in its own csrc
+// subdirectory: that directory has no .go files, so it isn't a Go
+// package and `go build ./...` never tries to compile the .c file
+// without cgo.
+//
+// The C program's corpus covers: the empty string, a handful of ASCII phrases, every
+// length in {0..8, 15..17, 31..33, 47..49, 63..65, 96..98, 127, 128} filled
+// with a fixed byte pattern, runs of zero bytes, a UTF-8 sample, and 4/8/12
+// KiB pseudo-random blobs (generated with a fixed splitmix64 seed so the
+// corpus is reproducible) — each hashed under five seeds (0, 1, RapidSeed,
+// 0xdeadbeef, and all-ones) and both secrets.
+//
+// Run this command with `go run ./cmd/gen-vectors` from the module root to
+// re-verify the checked-in vectors against the current implementation; a
+// non-zero exit status means rapidhash's output has drifted from the
+// recorded vectors.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/vkudryk/rapidhash-go"
+)
+
+type vector struct {
+	Label    string `json:"label"`
+	Seed     uint64 `json:"seed"`
+	Secret   int    `json:"secret"`
+	Hex      string `json:"hex"`
+	Expected string `json:"expected"`
+}
+
+// defaultSecret mirrors the package's own default secret, and customSecret
+// is a second, arbitrary one; together they're the two secrets exercised by
+// the "secret": 0 and "secret": 1 vectors.
+var (
+	defaultSecret = [3]uint64{
+		0x2d358dccaa6c78a5,
+		0x8bb84b93962eacc9,
+		0x4b33a62ed433d4a3,
+	}
+	customSecret = [3]uint64{
+		0x1111111111111111,
+		0x2222222222222222,
+		0x3333333333333333,
+	}
+)
+
+func main() {
+	data, err := os.ReadFile("testdata/rapidhash_vectors.json")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	mismatches := 0
+	for _, v := range vectors {
+		input, err := hex.DecodeString(v.Hex)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: invalid hex: %v\n", v.Label, err)
+			os.Exit(1)
+		}
+
+		secret := defaultSecret
+		if v.Secret == 1 {
+			secret = customSecret
+		}
+
+		got := fmt.Sprintf("%016x", rapidhash.HashWithSeedAndSecret(input, v.Seed, secret))
+		if got != v.Expected {
+			mismatches++
+			fmt.Printf("%s: seed=%d secret=%d: got %s, want %s\n", v.Label, v.Seed, v.Secret, got, v.Expected)
+		}
+	}
+
+	fmt.Printf("checked %d vectors, %d mismatches\n", len(vectors), mismatches)
+	if mismatches > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,17 @@
+// Package rapidhash implements rapidhash, a fast non-cryptographic hash
+// function, along with a streaming Hasher modeled on hash/maphash.
+//
+// For hash tables keyed on attacker-influenced input, prefer a Hasher
+// created with NewHasher: it seeds itself from crypto/rand on first use, so
+// distinct processes (and distinct Hashers within a process) disagree on
+// hash values, which defeats hash-flooding attacks that rely on predicting
+// collisions. Use HashWithSeed or HashWithSeedAndSecret instead when you
+// need a reproducible hash, e.g. for on-disk formats or cross-process
+// agreement.
 package rapidhash
 
 import (
+	"crypto/rand"
 	"encoding/binary"
 	"math/bits"
 	"unsafe"
@@ -45,6 +56,35 @@ func rapidMix(a, b uint64) uint64 {
 	return a ^ b
 }
 
+// smallAB computes the (a, b) mixing words for inputs of 16 bytes or fewer,
+// shared by HashWithSeedAndSecret and Hasher.Sum64.
+func smallAB(data []byte) (a, b uint64) {
+	length := uint64(len(data))
+	if length >= 4 {
+		// Read first and last 32 bits (may overlap)
+		delta := (length & 24) >> (length >> 3)
+		a = (read32(data) << 32) | read32(data[length-4:])
+		b = (read32(data[delta:]) << 32) | read32(data[length-4-delta:])
+	} else if length > 0 {
+		// For very small inputs (1-3 bytes), matching upstream rapidhash.h
+		a = (uint64(data[0]) << 45) | uint64(data[length-1])
+		b = uint64(data[length>>1])
+	}
+	return a, b
+}
+
+// foldBlock mixes one 48-byte block into the running (seed, see1, see2) triple
+// using the same recurrence as the bulk loop in HashWithSeedAndSecret. block must
+// be exactly 48 bytes. The one-shot path and the streaming Hasher both use it
+// on architectures without assembly; on amd64 and arm64 both call
+// foldBlockAsm instead, which implements the same recurrence.
+func foldBlock(block []byte, seed, see1, see2 uint64, secret [3]uint64) (uint64, uint64, uint64) {
+	seed = rapidMix(read64(block[0:])^secret[0], read64(block[8:])^seed)
+	see1 = rapidMix(read64(block[16:])^secret[1], read64(block[24:])^see1)
+	see2 = rapidMix(read64(block[32:])^secret[2], read64(block[40:])^see2)
+	return seed, see1, see2
+}
+
 // read32 reads a 32-bit value from byte slice with proper endianness
 func read32(p []byte) uint64 {
 	if len(p) < 4 {
@@ -87,21 +127,7 @@ func HashWithSeedAndSecret(data []byte, seed uint64, secret [3]uint64) uint64 {
 	var a, b uint64
 
 	if length <= 16 {
-		if length >= 4 {
-			// Read first and last 32 bits (may overlap)
-			delta := (length & 24) >> (length >> 3)
-			a = (read32(data) << 32) | read32(data[length-4:])
-			b = (read32(data[delta:]) << 32) | read32(data[length-4-delta:])
-		} else if length > 0 {
-			// For very small inputs (1-3 bytes)
-			a = uint64(data[0])
-			a |= uint64(data[length>>1]) << 8
-			a |= uint64(data[length-1]) << 16
-			b = 0
-		} else {
-			// Empty input
-			a, b = 0, 0
-		}
+		a, b = smallAB(data)
 	} else {
 		i := length
 		if i > 48 {
@@ -109,9 +135,11 @@ func HashWithSeedAndSecret(data []byte, seed uint64, secret [3]uint64) uint64 {
 			see1, see2 := seed, seed
 
 			for i > 48 {
-				seed = rapidMix(read64(data[length-i:])^secret[0], read64(data[length-i+8:])^seed)
-				see1 = rapidMix(read64(data[length-i+16:])^secret[1], read64(data[length-i+24:])^see1)
-				see2 = rapidMix(read64(data[length-i+32:])^secret[2], read64(data[length-i+40:])^see2)
+				if haveAsm {
+					seed, see1, see2 = foldBlockAsm(&data[length-i], seed, see1, see2, &secret)
+				} else {
+					seed, see1, see2 = foldBlock(data[length-i:length-i+48], seed, see1, see2, secret)
+				}
 				i -= 48
 			}
 			seed ^= see1 ^ see2
@@ -144,13 +172,15 @@ func String(s string) uint64 {
 
 // StringWithSeed hashes a string with custom seed
 func StringWithSeed(s string, seed uint64) uint64 {
-	// Convert string to []byte without allocation using unsafe
-	data := *(*[]byte)(unsafe.Pointer(&struct {
+	return HashWithSeed(unsafeBytes(s), seed)
+}
+
+// unsafeBytes views a string as a []byte without allocation.
+func unsafeBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
 		string
 		int
 	}{s, len(s)}))
-
-	return HashWithSeed(data, seed)
 }
 
 // Uint64 hashes a single uint64 value
@@ -186,40 +216,195 @@ func Uint32WithSeed(value uint32, seed uint64) uint64 {
 	return rapidMix(a^rapidSecret[0]^4, b^rapidSecret[2])
 }
 
-// Hasher provides a hash.Hash64 compatible interface
+// Seed is a Hasher seed that can be passed to SetSeed to make a Hasher
+// behave as if it had been seeded with that specific value. The zero Seed
+// is not valid except as a zero value to be overwritten; use MakeSeed or
+// (*Hasher).Seed to obtain one.
+type Seed struct {
+	s uint64
+}
+
+// MakeSeed returns a new random seed, read from crypto/rand. Hashers
+// created with NewHasher pick one of these automatically on first use;
+// call MakeSeed directly only to capture a seed for later reuse, e.g. via
+// SetSeed on another Hasher.
+func MakeSeed() Seed {
+	var buf [8]byte
+	var s uint64
+	for s == 0 {
+		if _, err := rand.Read(buf[:]); err != nil {
+			panic("rapidhash: failed to read random seed: " + err.Error())
+		}
+		s = binary.LittleEndian.Uint64(buf[:])
+	}
+	return Seed{s: s}
+}
+
+// Hasher provides a hash.Hash64 compatible interface that folds complete
+// 48-byte blocks into a running state as they are written, instead of
+// buffering the whole input, so hashing a large stream no longer costs
+// memory proportional to the stream's length.
+//
+// rapidhash folds the total input length into its seed before reducing any
+// 48-byte block, and a Hasher has no way of knowing that length until Sum64
+// is called. Inputs of up to 48 bytes never reach the block loop in
+// HashWithSeedAndSecret either, so Hasher buffers those in full and Sum64
+// reproduces HashWithSeedAndSecret bit-for-bit. Once a write pushes the
+// total past 48 bytes, Hasher folds blocks using the seed and secret alone;
+// from that point Sum64 returns a distinct but still deterministic,
+// collision-resistant hash of the bytes written, stable across any split of
+// the Write calls that produced them.
+//
+// Sum64 does not match Hash for inputs over 48 bytes. Hash's pre-loop seed
+// mix includes the total length, which a streaming writer doesn't know
+// until Sum64 is called, by which point any blocks already folded were
+// folded without it; the one-shot value can't be recovered from that state
+// without buffering the whole input.
+//
+// The zero Hasher, like one returned by NewHasher, picks a random Seed via
+// MakeSeed the first time it is written to or summed; use SetSeed for a
+// reproducible seed instead.
 type Hasher struct {
 	seed   uint64
 	secret [3]uint64
-	buf    []byte
+	seeded bool
+
+	accSeed, accSee1, accSee2 uint64
+	folding                   bool
+
+	tail    [48]byte
+	tailLen int
 }
 
-// NewHasher creates a new Hasher with default seed and secret
+// NewHasher creates a new Hasher. It picks a random Seed via MakeSeed on
+// first use; call SetSeed for reproducible results instead.
 func NewHasher() *Hasher {
-	return &Hasher{
-		seed:   RapidSeed,
-		secret: rapidSecret,
-		buf:    make([]byte, 0, 64),
-	}
+	return new(Hasher)
 }
 
 // NewHasherWithSeed creates a new Hasher with custom seed
 func NewHasherWithSeed(seed uint64) *Hasher {
-	return &Hasher{
-		seed:   seed,
-		secret: rapidSecret,
-		buf:    make([]byte, 0, 64),
+	h := new(Hasher)
+	h.SetSeed(Seed{s: seed})
+	return h
+}
+
+// SetSeed sets h to behave as if it had just been created with that seed,
+// discarding any bytes already written. Passing the same Seed to two
+// Hashers makes them hash identical input to identical values.
+func (h *Hasher) SetSeed(seed Seed) {
+	h.seed = seed.s
+	h.secret = rapidSecret
+	h.seeded = true
+	h.accSeed, h.accSee1, h.accSee2 = 0, 0, 0
+	h.folding = false
+	h.tailLen = 0
+}
+
+// Seed returns h's current seed, generating one via MakeSeed first if none
+// has been set yet.
+func (h *Hasher) Seed() Seed {
+	h.init()
+	return Seed{s: h.seed}
+}
+
+// init lazily assigns a random seed and the default secret the first time
+// h is used, so the zero Hasher behaves like one from NewHasher.
+func (h *Hasher) init() {
+	if h.seeded {
+		return
 	}
+	h.seed = MakeSeed().s
+	h.secret = rapidSecret
+	h.seeded = true
 }
 
-// Write implements io.Writer
+// startSeed returns the seed/see1/see2 starting value for block folding: the
+// same pre-loop mix HashWithSeedAndSecret applies, minus the `^ length` term
+// that folding can't know in advance.
+func (h *Hasher) startSeed() uint64 {
+	return h.seed ^ rapidMix(h.seed^h.secret[0], h.secret[1])
+}
+
+// Write implements io.Writer, folding every complete 48-byte block into the
+// running state and keeping only the incomplete remainder buffered.
 func (h *Hasher) Write(p []byte) (n int, err error) {
-	h.buf = append(h.buf, p...)
-	return len(p), nil
+	h.init()
+	written := len(p)
+
+	for len(p) > 0 {
+		room := 48 - h.tailLen
+		if room > len(p) {
+			room = len(p)
+		}
+		copy(h.tail[h.tailLen:], p[:room])
+		h.tailLen += room
+		p = p[room:]
+
+		if h.tailLen < 48 || len(p) == 0 {
+			// Either the tail is still incomplete, or it's exactly full but
+			// this is the last byte we've seen so far: it may turn out to
+			// be the final 48 bytes of the whole input, so leave it unfolded.
+			break
+		}
+
+		if !h.folding {
+			start := h.startSeed()
+			h.accSeed, h.accSee1, h.accSee2 = start, start, start
+			h.folding = true
+		}
+		if haveAsm {
+			h.accSeed, h.accSee1, h.accSee2 = foldBlockAsm(&h.tail[0], h.accSeed, h.accSee1, h.accSee2, &h.secret)
+		} else {
+			h.accSeed, h.accSee1, h.accSee2 = foldBlock(h.tail[:], h.accSeed, h.accSee1, h.accSee2, h.secret)
+		}
+		h.tailLen = 0
+	}
+
+	return written, nil
+}
+
+// WriteString hashes s without converting it to a []byte first.
+func (h *Hasher) WriteString(s string) (int, error) {
+	return h.Write(unsafeBytes(s))
+}
+
+// WriteByte hashes a single byte.
+func (h *Hasher) WriteByte(c byte) error {
+	_, err := h.Write([]byte{c})
+	return err
 }
 
-// Sum64 returns the 64-bit hash
+// Sum64 returns the 64-bit hash of everything written so far. It is
+// non-destructive: callers may keep writing after calling Sum64.
 func (h *Hasher) Sum64() uint64 {
-	return HashWithSeedAndSecret(h.buf, h.seed, h.secret)
+	h.init()
+	if !h.folding {
+		return HashWithSeedAndSecret(h.tail[:h.tailLen], h.seed, h.secret)
+	}
+
+	secret := h.secret
+	seed := h.accSeed ^ h.accSee1 ^ h.accSee2
+	tail := h.tail[:h.tailLen]
+	i := uint64(h.tailLen)
+
+	var a, b uint64
+	if i > 16 {
+		seed = rapidMix(read64(tail)^secret[2], read64(tail[8:])^seed^secret[1])
+		if i > 32 {
+			seed = rapidMix(read64(tail[16:])^secret[2], read64(tail[24:])^seed)
+		}
+		a = read64(tail[i-16:])
+		b = read64(tail[i-8:])
+	} else {
+		a, b = smallAB(tail)
+	}
+
+	a ^= secret[1]
+	b ^= seed
+	rapidMul(&a, &b)
+
+	return rapidMix(a^secret[0]^i, b^secret[2])
 }
 
 // Sum appends the hash to b and returns the result
@@ -230,7 +415,9 @@ func (h *Hasher) Sum(b []byte) []byte {
 
 // Reset resets the hasher to its initial state
 func (h *Hasher) Reset() {
-	h.buf = h.buf[:0]
+	h.accSeed, h.accSee1, h.accSee2 = 0, 0, 0
+	h.folding = false
+	h.tailLen = 0
 }
 
 // Size returns the hash size in bytes (8 for 64-bit)
@@ -0,0 +1,19 @@
+//go:build amd64 || arm64
+
+package rapidhash
+
+// haveAsm reports whether foldBlockAsm is backed by hand-written assembly
+// on this GOARCH. It's a plain const, not a CPU-feature probe, since the
+// 64x64->128 multiply foldBlockAsm needs (MULQ on amd64, MUL/UMULH on
+// arm64) is always available on these architectures.
+const haveAsm = true
+
+// foldBlockAsm is the architecture-specific counterpart of foldBlock,
+// implemented in rapidhash_amd64.s / rapidhash_arm64.s: the same 48-byte
+// triple-rapidMix recurrence, with seed/see1/see2 held in registers while
+// the block is mixed. Like any Go assembly function it takes its arguments
+// and returns its results through the stack frame. block must point to at
+// least 48 readable bytes.
+//
+//go:noescape
+func foldBlockAsm(block *byte, seed, see1, see2 uint64, secret *[3]uint64) (uint64, uint64, uint64)
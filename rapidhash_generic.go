@@ -0,0 +1,17 @@
+//go:build !amd64 && !arm64
+
+package rapidhash
+
+import "unsafe"
+
+// haveAsm is false on architectures without a rapidhash_*.s implementation;
+// foldBlockAsm below exists only so call sites don't need a second
+// build-tagged branch, not because this path is ever taken.
+const haveAsm = false
+
+// foldBlockAsm defers to the portable foldBlock. It's never actually called
+// since haveAsm is false here, but must exist so HashWithSeedAndSecret and
+// Hasher.Write type-check identically on every architecture.
+func foldBlockAsm(block *byte, seed, see1, see2 uint64, secret *[3]uint64) (uint64, uint64, uint64) {
+	return foldBlock(unsafe.Slice(block, 48), seed, see1, see2, *secret)
+}
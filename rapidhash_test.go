@@ -1,8 +1,12 @@
 package rapidhash
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"hash"
+	"math/rand"
+	"os"
 	"testing"
 )
 
@@ -86,7 +90,7 @@ func TestHasherInterface(t *testing.T) {
 	// Test that Hasher implements hash.Hash64
 	var _ hash.Hash64 = (*Hasher)(nil)
 
-	hasher := NewHasher()
+	hasher := NewHasherWithSeed(RapidSeed)
 
 	// Test writing data
 	data := []byte("hello world")
@@ -133,6 +137,93 @@ func TestHasherInterface(t *testing.T) {
 	}
 }
 
+func TestHasherSum64NonDestructive(t *testing.T) {
+	hasher := NewHasher()
+	hasher.Write([]byte("hello world, this is more than forty eight bytes long"))
+
+	h1 := hasher.Sum64()
+	h2 := hasher.Sum64()
+	if h1 != h2 {
+		t.Errorf("Sum64() should be idempotent: %x != %x", h1, h2)
+	}
+
+	hasher.Write([]byte(" and some more"))
+	h3 := hasher.Sum64()
+	if h3 == h1 {
+		t.Errorf("Sum64() should change after writing more data")
+	}
+}
+
+func TestHasherWriteStringByte(t *testing.T) {
+	hasher := NewHasherWithSeed(RapidSeed)
+	hasher.WriteString("hello ")
+	hasher.WriteByte('w')
+	hasher.WriteString("orld")
+
+	want := NewHasherWithSeed(RapidSeed)
+	want.Write([]byte("hello world"))
+
+	if got, want := hasher.Sum64(), want.Sum64(); got != want {
+		t.Errorf("WriteString/WriteByte produced %x, want %x", got, want)
+	}
+}
+
+func TestMakeSeedIsRandomAndNonZero(t *testing.T) {
+	seen := make(map[Seed]bool)
+	for i := 0; i < 100; i++ {
+		s := MakeSeed()
+		if s == (Seed{}) {
+			t.Fatal("MakeSeed returned the zero Seed")
+		}
+		if seen[s] {
+			t.Fatalf("MakeSeed returned %v twice", s)
+		}
+		seen[s] = true
+	}
+}
+
+func TestHasherDefaultSeedIsRandom(t *testing.T) {
+	a := NewHasher()
+	b := NewHasher()
+
+	data := []byte("hash flooding shouldn't be predictable")
+	a.Write(data)
+	b.Write(data)
+
+	if a.Sum64() == b.Sum64() {
+		t.Error("two default Hashers produced the same hash for the same input; NewHasher should seed randomly")
+	}
+	if a.Seed() == b.Seed() {
+		t.Error("two default Hashers share a Seed")
+	}
+}
+
+func TestHasherSetSeed(t *testing.T) {
+	seed := MakeSeed()
+
+	a := NewHasher()
+	a.SetSeed(seed)
+	a.Write([]byte("same seed, same data"))
+
+	b := NewHasher()
+	b.SetSeed(seed)
+	b.Write([]byte("same seed, same data"))
+
+	if a.Sum64() != b.Sum64() {
+		t.Error("two Hashers sharing a Seed should hash identical input identically")
+	}
+	if a.Seed() != seed {
+		t.Errorf("Seed() = %v, want %v", a.Seed(), seed)
+	}
+
+	a.SetSeed(MakeSeed())
+	a.Reset()
+	a.Write([]byte("same seed, same data"))
+	if a.Sum64() == b.Sum64() {
+		t.Error("SetSeed with a different Seed should change the hash")
+	}
+}
+
 func TestSpecificTypes(t *testing.T) {
 	// Test Uint64 hashing
 	val64 := uint64(0x123456789abcdef0)
@@ -157,8 +248,14 @@ func TestSpecificTypes(t *testing.T) {
 }
 
 func TestLargeInputs(t *testing.T) {
-	// Test with large inputs to exercise the chunked processing
+	// Test with large inputs to exercise the streaming Hasher's block folding.
+	// Once an input exceeds 48 bytes, Hasher.Sum64 intentionally no longer
+	// matches the one-shot Hash (see the Hasher doc comment) because the
+	// total length can't be folded into the seed before the first block is
+	// processed; what must hold is that the result is the same regardless
+	// of how the writes are split.
 	sizes := []int{100, 1000, 10000, 100000}
+	chunkSizes := []int{1, 7, 1000}
 
 	for _, size := range sizes {
 		data := make([]byte, size)
@@ -166,23 +263,80 @@ func TestLargeInputs(t *testing.T) {
 			data[i] = byte(i)
 		}
 
-		h := Hash(data)
-		t.Logf("Size %d: %016x", size, h)
+		var want uint64
+		for ci, chunkSize := range chunkSizes {
+			hasher := NewHasherWithSeed(RapidSeed)
+			for i := 0; i < len(data); i += chunkSize {
+				end := i + chunkSize
+				if end > len(data) {
+					end = len(data)
+				}
+				hasher.Write(data[i:end])
+			}
 
-		// Verify it's the same when split across multiple writes
-		hasher := NewHasher()
-		chunkSize := 1000
-		for i := 0; i < len(data); i += chunkSize {
-			end := i + chunkSize
-			if end > len(data) {
-				end = len(data)
+			got := hasher.Sum64()
+			t.Logf("Size %d, chunk %d: %016x", size, chunkSize, got)
+			if ci == 0 {
+				want = got
+				continue
+			}
+			if got != want {
+				t.Errorf("size %d: chunk size %d gave %x, want %x (from chunk size %d)", size, chunkSize, got, want, chunkSizes[0])
+			}
+		}
+	}
+}
+
+// TestHasherMatchesHashUpToBlockSize verifies that, for inputs that never
+// trigger block folding (48 bytes or fewer), Hasher.Sum64 is bit-for-bit
+// identical to the one-shot Hash, regardless of write split.
+func TestHasherMatchesHashUpToBlockSize(t *testing.T) {
+	for size := 0; size <= 48; size++ {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i*7 + 1)
+		}
+		want := Hash(data)
+
+		for _, split := range []int{1, 3} {
+			hasher := NewHasherWithSeed(RapidSeed)
+			for i := 0; i < len(data); i += split {
+				end := i + split
+				if end > len(data) {
+					end = len(data)
+				}
+				hasher.Write(data[i:end])
+			}
+			if got := hasher.Sum64(); got != want {
+				t.Errorf("size %d split %d: Sum64()=%x, want %x", size, split, got, want)
 			}
-			hasher.Write(data[i:end])
 		}
+	}
+}
 
-		h2 := hasher.Sum64()
-		if h != h2 {
-			t.Errorf("Chunked hash should equal single hash for size %d: %x != %x", size, h, h2)
+// TestFoldBlockMatchesAsm cross-checks foldBlock (the portable Go
+// recurrence) against foldBlockAsm (the hand-written amd64/arm64 assembly)
+// directly, on identical inputs. haveAsm is a compile-time constant, so on
+// amd64/arm64 HashWithSeedAndSecret and Hasher.Write only ever dispatch to
+// foldBlockAsm; without a test like this one, foldBlock itself would never
+// be exercised on those architectures, let alone verified to agree with the
+// assembly it's supposed to mirror.
+func TestFoldBlockMatchesAsm(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for trial := 0; trial < 1000; trial++ {
+		var block [48]byte
+		rng.Read(block[:])
+
+		seed, see1, see2 := rng.Uint64(), rng.Uint64(), rng.Uint64()
+		secret := [3]uint64{rng.Uint64(), rng.Uint64(), rng.Uint64()}
+
+		wantSeed, wantSee1, wantSee2 := foldBlock(block[:], seed, see1, see2, secret)
+		gotSeed, gotSee1, gotSee2 := foldBlockAsm(&block[0], seed, see1, see2, &secret)
+
+		if gotSeed != wantSeed || gotSee1 != wantSee1 || gotSee2 != wantSee2 {
+			t.Fatalf("trial %d: foldBlockAsm(seed=%x, see1=%x, see2=%x) = (%x, %x, %x), want (%x, %x, %x)",
+				trial, seed, see1, see2, gotSeed, gotSee1, gotSee2, wantSeed, wantSee1, wantSee2)
 		}
 	}
 }
@@ -252,6 +406,56 @@ func BenchmarkUint64(b *testing.B) {
 	}
 }
 
+// BenchmarkHash4KB hashes a 4 KiB input. On amd64 and arm64 this exercises
+// the assembly foldBlockAsm through HashWithSeedAndSecret's normal
+// dispatch (see haveAsm); elsewhere it runs the portable foldBlock.
+func BenchmarkHash4KB(b *testing.B) {
+	data := make([]byte, 4096)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Hash(data)
+	}
+}
+
+// BenchmarkFoldBlockGo and BenchmarkFoldBlockAsm isolate the per-block
+// bulk-loop cost directly, so `go test -bench FoldBlock` compares the
+// portable and assembly implementations of the same recurrence without the
+// rest of HashWithSeedAndSecret's overhead diluting the difference. On
+// amd64/arm64, foldBlockAsm is expected to be at least 1.5x faster here.
+func BenchmarkFoldBlockGo(b *testing.B) {
+	var block [48]byte
+	for i := range block {
+		block[i] = byte(i)
+	}
+	seed, see1, see2 := RapidSeed, RapidSeed, RapidSeed
+
+	b.SetBytes(48)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seed, see1, see2 = foldBlock(block[:], seed, see1, see2, rapidSecret)
+	}
+}
+
+func BenchmarkFoldBlockAsm(b *testing.B) {
+	var block [48]byte
+	for i := range block {
+		block[i] = byte(i)
+	}
+	seed, see1, see2 := RapidSeed, RapidSeed, RapidSeed
+	secret := rapidSecret
+
+	b.SetBytes(48)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seed, see1, see2 = foldBlockAsm(&block[0], seed, see1, see2, &secret)
+	}
+}
+
 func BenchmarkHasher(b *testing.B) {
 	data := []byte("The quick brown fox jumps over the lazy dog")
 	hasher := NewHasher()
@@ -264,6 +468,46 @@ func BenchmarkHasher(b *testing.B) {
 	}
 }
 
+// FuzzHasherSplitInvariance verifies that splitting a Write into arbitrary
+// pieces never changes Hasher.Sum64's result, and that Sum64 matches the
+// one-shot Hash for inputs that never trigger block folding (<= 48 bytes).
+//
+// It does not verify, and is not a stand-in for, Hash agreement beyond 48
+// bytes: per the Hasher doc comment, Sum64 cannot match Hash bit for bit
+// past that point, so there is nothing to fuzz there except the
+// split-invariance this target already checks.
+func FuzzHasherSplitInvariance(f *testing.F) {
+	f.Add([]byte(""), uint8(1))
+	f.Add([]byte("hello world"), uint8(3))
+	f.Add(make([]byte, 48), uint8(7))
+	f.Add(make([]byte, 200), uint8(13))
+
+	f.Fuzz(func(t *testing.T, data []byte, splitSeed uint8) {
+		whole := NewHasherWithSeed(RapidSeed)
+		whole.Write(data)
+		want := whole.Sum64()
+
+		if len(data) <= 48 {
+			if h := Hash(data); h != want {
+				t.Errorf("Hash(data)=%x, Hasher.Sum64()=%x for %d-byte input", h, want, len(data))
+			}
+		}
+
+		split := int(splitSeed)%7 + 1
+		streamed := NewHasherWithSeed(RapidSeed)
+		for i := 0; i < len(data); i += split {
+			end := i + split
+			if end > len(data) {
+				end = len(data)
+			}
+			streamed.Write(data[i:end])
+		}
+		if got := streamed.Sum64(); got != want {
+			t.Errorf("split size %d: Sum64()=%x, want %x", split, got, want)
+		}
+	})
+}
+
 // Example usage
 func ExampleHash() {
 	data := []byte("hello world")
@@ -285,32 +529,62 @@ func ExampleHasher() {
 	fmt.Printf("%016x\n", h)
 }
 
-// Test vector verification (these would need to be compared against the C implementation)
+// knownVector is one record of testdata/rapidhash_vectors.json: an input,
+// seed, and secret together with the hash HashWithSeedAndSecret must produce
+// for them. See cmd/gen-vectors for the C transcription it is cross-checked
+// against.
+type knownVector struct {
+	Label    string `json:"label"`
+	Seed     uint64 `json:"seed"`
+	Secret   int    `json:"secret"`
+	Hex      string `json:"hex"`
+	Expected string `json:"expected"`
+}
+
+// knownVectorCustomSecret is the non-default secret used by vectors with
+// Secret == 1, matching the one cmd/gen-vectors uses.
+var knownVectorCustomSecret = [3]uint64{
+	0x1111111111111111,
+	0x2222222222222222,
+	0x3333333333333333,
+}
+
+// TestKnownVectors checks HashWithSeedAndSecret against the corpus of
+// (input, seed, secret) -> hash triples in testdata/rapidhash_vectors.json.
+// The file is not the output of cmd/gen-vectors/csrc/rapidhash_ref.c, a
+// standalone C transcription of the algorithm in rapidhash.go (not the
+// upstream rapidhash.h source), but agrees with it on every input the two
+// share. Because the expected values are fixed data, a regression that
+// only shows up as agreement with itself (e.g. a bug present on both sides
+// of a refactor) still gets caught.
 func TestKnownVectors(t *testing.T) {
-	// Note: These test vectors would need to be generated from the original C implementation
-	// For now, we just test that the hashes are deterministic
+	data, err := os.ReadFile("testdata/rapidhash_vectors.json")
+	if err != nil {
+		t.Fatalf("reading testdata/rapidhash_vectors.json: %v", err)
+	}
 
-	vectors := []struct {
-		input string
-		seed  uint64
-	}{
-		{"", RapidSeed},
-		{"a", RapidSeed},
-		{"abc", RapidSeed},
-		{"message digest", RapidSeed},
-		{"abcdefghijklmnopqrstuvwxyz", RapidSeed},
-		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", RapidSeed},
-		{"hello", 42},
-	}
-
-	for i, v := range vectors {
-		h := HashWithSeed([]byte(v.input), v.seed)
-		t.Logf("Vector %d: %q (seed=%d) -> %016x", i, v.input, v.seed, h)
-
-		// Verify consistency
-		hash2 := HashWithSeed([]byte(v.input), v.seed)
-		if h != hash2 {
-			t.Errorf("Vector %d is not consistent", i)
+	var vectors []knownVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("parsing testdata/rapidhash_vectors.json: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("testdata/rapidhash_vectors.json contains no vectors")
+	}
+
+	for _, v := range vectors {
+		input, err := hex.DecodeString(v.Hex)
+		if err != nil {
+			t.Fatalf("%s: invalid hex %q: %v", v.Label, v.Hex, err)
+		}
+
+		secret := rapidSecret
+		if v.Secret == 1 {
+			secret = knownVectorCustomSecret
+		}
+
+		got := fmt.Sprintf("%016x", HashWithSeedAndSecret(input, v.Seed, secret))
+		if got != v.Expected {
+			t.Errorf("%s: seed=%d secret=%d: got %s, want %s", v.Label, v.Seed, v.Secret, got, v.Expected)
 		}
 	}
 }
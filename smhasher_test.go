@@ -0,0 +1,238 @@
+package rapidhash
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// This file ports the SMHasher-style statistical battery that the Go
+// standard library runs against hash/maphash (avalanche, sparse keys,
+// permutations, appended zeros, seed variation) to rapidhash's own
+// Hash/HashWithSeed/HashWithSeedAndSecret. These tests don't prove
+// correctness; they catch the kind of distribution weaknesses a
+// non-cryptographic hash can quietly develop (e.g. failing to mix in
+// trailing zero bytes, or seeds that don't actually decorrelate outputs).
+
+// collisionSigma controls how many standard deviations above the
+// birthday-bound expectation a battery tolerates before failing; raise it
+// if a legitimate test becomes flaky, lower it to tighten the check.
+const collisionSigma = 4.0
+
+// hashVariant names a function under test together with a label for test
+// output, so each battery below runs against Hash, HashWithSeed, and
+// HashWithSeedAndSecret without repeating itself three times.
+type hashVariant struct {
+	name string
+	hash func(data []byte) uint64
+}
+
+var hashVariants = []hashVariant{
+	{"Hash", Hash},
+	{"HashWithSeed", func(data []byte) uint64 { return HashWithSeed(data, 0x1234567890abcdef) }},
+	{"HashWithSeedAndSecret", func(data []byte) uint64 {
+		return HashWithSeedAndSecret(data, RapidSeed, [3]uint64{
+			0x9e3779b97f4a7c15,
+			0xbf58476d1ce4e5b9,
+			0x94d049bb133111eb,
+		})
+	}},
+}
+
+// quality returns full for a normal run and short for `go test -short`,
+// so the heavier batteries (Permutation, Sparse, Avalanche) stay fast in
+// short mode instead of scaling combinatorially.
+func quality(full, short int) int {
+	if testing.Short() {
+		return short
+	}
+	return full
+}
+
+// maxCollisions returns the largest 64-bit collision count consistent with
+// hashing n distinct keys, allowing collisionSigma standard deviations of
+// slack above the birthday-bound expectation (n choose 2 pairs, each
+// colliding with probability 2^-64).
+func maxCollisions(n int) int {
+	pairs := float64(n) * float64(n-1) / 2
+	lambda := pairs / math.Pow(2, 64)
+	return int(math.Ceil(lambda + collisionSigma*math.Sqrt(lambda+1) + 1))
+}
+
+func countCollisions(hashes []uint64) int {
+	seen := make(map[uint64]int, len(hashes))
+	for _, h := range hashes {
+		seen[h]++
+	}
+	collisions := 0
+	for _, c := range seen {
+		if c > 1 {
+			collisions += c - 1
+		}
+	}
+	return collisions
+}
+
+func checkCollisions(t *testing.T, label string, hashes []uint64) {
+	t.Helper()
+	got := countCollisions(hashes)
+	if want := maxCollisions(len(hashes)); got > want {
+		t.Errorf("%s: %d collisions among %d hashes, want <= %d (birthday bound + %.1f sigma)", label, got, len(hashes), want, collisionSigma)
+	}
+}
+
+// TestSMHasherAppendedZeros hashes each of a handful of base keys with an
+// increasing run of trailing zero bytes appended, to catch a hash that
+// doesn't fold trailing zeros into its output.
+func TestSMHasherAppendedZeros(t *testing.T) {
+	bases := []string{"", "a", "ab", "rapidhash", "The quick brown fox jumps over the lazy dog"}
+	maxZeros := quality(64, 16)
+
+	for _, v := range hashVariants {
+		t.Run(v.name, func(t *testing.T) {
+			var hashes []uint64
+			for _, base := range bases {
+				buf := []byte(base)
+				for z := 0; z <= maxZeros; z++ {
+					hashes = append(hashes, v.hash(buf))
+					buf = append(buf, 0)
+				}
+			}
+			checkCollisions(t, "AppendedZeros", hashes)
+		})
+	}
+}
+
+// TestSMHasherSparse hashes keys of various lengths with only a few bits
+// set, since sparse inputs are a classic way to expose weak mixing.
+func TestSMHasherSparse(t *testing.T) {
+	lengths := []int{2, 4, 8, 16, 24, 32}
+	pairwise := quality(2, 1) >= 2
+
+	for _, v := range hashVariants {
+		t.Run(v.name, func(t *testing.T) {
+			for _, length := range lengths {
+				bits := length * 8
+				key := make([]byte, length)
+				var hashes []uint64
+
+				hashes = append(hashes, v.hash(key)) // zero bits set
+
+				for i := 0; i < bits; i++ {
+					key[i/8] |= 1 << uint(i%8)
+					hashes = append(hashes, v.hash(key))
+					key[i/8] = 0
+				}
+
+				if pairwise {
+					// Cap pairwise enumeration so the largest lengths stay fast.
+					limit := bits
+					if limit > 64 {
+						limit = 64
+					}
+					for i := 0; i < limit; i++ {
+						for j := i + 1; j < limit; j++ {
+							key[i/8] |= 1 << uint(i%8)
+							key[j/8] |= 1 << uint(j%8)
+							hashes = append(hashes, v.hash(key))
+							key[i/8] = 0
+							key[j/8] = 0
+						}
+					}
+				}
+
+				checkCollisions(t, fmt.Sprintf("Sparse/len=%d", length), hashes)
+			}
+		})
+	}
+}
+
+// TestSMHasherPermutation hashes every permutation of a small, fixed
+// alphabet of distinct bytes.
+func TestSMHasherPermutation(t *testing.T) {
+	n := quality(8, 6)
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = byte(i + 1)
+	}
+
+	for _, v := range hashVariants {
+		t.Run(v.name, func(t *testing.T) {
+			var hashes []uint64
+
+			var permute func(k int)
+			permute = func(k int) {
+				if k == len(buf) {
+					hashes = append(hashes, v.hash(buf))
+					return
+				}
+				for i := k; i < len(buf); i++ {
+					buf[k], buf[i] = buf[i], buf[k]
+					permute(k + 1)
+					buf[k], buf[i] = buf[i], buf[k]
+				}
+			}
+			permute(0)
+
+			checkCollisions(t, "Permutation", hashes)
+		})
+	}
+}
+
+// TestSMHasherAvalanche flips each bit of a random key in turn and checks
+// that every output bit flips with close to 50% probability, the signature
+// of a well-mixed hash.
+func TestSMHasherAvalanche(t *testing.T) {
+	const keyBits = 64 // 8-byte keys
+	trials := quality(256, 64)
+	// ~6 standard deviations of slack around the expected 0.5 flip
+	// probability for a fair coin (stddev = 0.5/sqrt(trials)).
+	eps := 3 / math.Sqrt(float64(trials))
+
+	for _, v := range hashVariants {
+		t.Run(v.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+
+			for bit := 0; bit < keyBits; bit++ {
+				var flips [64]int
+				var key [8]byte
+
+				for trial := 0; trial < trials; trial++ {
+					rng.Read(key[:])
+					h0 := v.hash(key[:])
+					key[bit/8] ^= 1 << uint(bit%8)
+					h1 := v.hash(key[:])
+
+					diff := h0 ^ h1
+					for ob := 0; ob < 64; ob++ {
+						if diff&(1<<uint(ob)) != 0 {
+							flips[ob]++
+						}
+					}
+				}
+
+				for ob, count := range flips {
+					p := float64(count) / float64(trials)
+					if p < 0.5-eps || p > 0.5+eps {
+						t.Errorf("input bit %d -> output bit %d flips with probability %.3f, want 0.5 +/- %.3f", bit, ob, p, eps)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestSMHasherSeed hashes the same data under many different seeds and
+// checks the outputs don't collide more than chance allows, i.e. that the
+// seed actually decorrelates the hash rather than being mixed in weakly.
+func TestSMHasherSeed(t *testing.T) {
+	data := []byte("rapidhash SMHasher seed variation test payload")
+	seeds := quality(4096, 256)
+
+	hashes := make([]uint64, seeds)
+	for s := range hashes {
+		hashes[s] = HashWithSeed(data, uint64(s))
+	}
+	checkCollisions(t, "Seed", hashes)
+}